@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCommitVoteRejectsInconsistentPendingSteps(t *testing.T) {
+	ctx, stub := newTestContext(t)
+	contract := new(VoteAuthContract)
+
+	steps := map[string]PendingVerification{
+		"id_verification":   {VoterUUID: "voter-1", VoterID: "id-1", PollingBoothID: 1, Step: "id_verification"},
+		"face_verification": {VoterUUID: "voter-1", VoterID: "id-1", PollingBoothID: 1, Step: "face_verification"},
+		// iris_verification disagrees on PollingBoothID with the other two steps.
+		"iris_verification": {VoterUUID: "voter-1", VoterID: "id-1", PollingBoothID: 2, Step: "iris_verification"},
+	}
+	for step, pending := range steps {
+		pendingJSON, err := json.Marshal(pending)
+		if err != nil {
+			t.Fatalf("marshal pending %s: %v", step, err)
+		}
+		if err := stub.PutState(pendingVerificationKey("voter-1", step), pendingJSON); err != nil {
+			t.Fatalf("put pending %s: %v", step, err)
+		}
+	}
+
+	if err := contract.CommitVote(ctx, "voter-1"); err == nil {
+		t.Fatal("CommitVote accepted pending verification steps with inconsistent voter_id/polling_booth_id")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func newTestContext(t *testing.T) (*contractapi.TransactionContext, *shimtest.MockStub) {
+	t.Helper()
+	stub := shimtest.NewMockStub("voteauth", nil)
+	stub.MockTransactionStart("tx1")
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	return ctx, stub
+}
+
+func putVoteRecord(t *testing.T, stub *shimtest.MockStub, record VoteRecord) {
+	t.Helper()
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("marshal vote record: %v", err)
+	}
+	if err := stub.PutState("vote_"+record.VoterUUID, recordJSON); err != nil {
+		t.Fatalf("put vote record: %v", err)
+	}
+}
+
+func TestVerifyVoteIntegrityValidChain(t *testing.T) {
+	ctx, stub := newTestContext(t)
+	contract := new(VoteAuthContract)
+
+	first := VoteRecord{VoterUUID: "voter-1", PreviousHash: ""}
+	first.BlockchainHash = contract.generateHash(first)
+	putVoteRecord(t, stub, first)
+
+	second := VoteRecord{VoterUUID: "voter-2", PreviousHash: first.BlockchainHash}
+	second.Timestamp = first.Timestamp.Add(1)
+	second.BlockchainHash = contract.generateHash(second)
+	putVoteRecord(t, stub, second)
+
+	result, err := contract.VerifyVoteIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("VerifyVoteIntegrity returned error on a valid chain: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("VerifyVoteIntegrity reported an invalid chain that should be valid: %+v", result)
+	}
+	if result.Height != 2 {
+		t.Fatalf("expected height 2, got %d", result.Height)
+	}
+}
+
+func TestVerifyVoteIntegrityDetectsTamperedHash(t *testing.T) {
+	ctx, stub := newTestContext(t)
+	contract := new(VoteAuthContract)
+
+	record := VoteRecord{VoterUUID: "voter-1", PreviousHash: ""}
+	record.BlockchainHash = contract.generateHash(record)
+	// Tamper with a field covered by the hash after computing it, so the
+	// stored hash no longer matches its own record.
+	record.PollingBoothID = 99
+	putVoteRecord(t, stub, record)
+
+	result, err := contract.VerifyVoteIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("VerifyVoteIntegrity returned error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("VerifyVoteIntegrity did not detect a tampered record's hash")
+	}
+	if result.Height != 0 {
+		t.Fatalf("expected divergence at height 0, got %d", result.Height)
+	}
+	if result.DivergentHash != record.BlockchainHash {
+		t.Fatalf("expected divergent hash %s, got %s", record.BlockchainHash, result.DivergentHash)
+	}
+}
+
+func TestVerifyVoteIntegrityDetectsBrokenLink(t *testing.T) {
+	ctx, stub := newTestContext(t)
+	contract := new(VoteAuthContract)
+
+	first := VoteRecord{VoterUUID: "voter-1", PreviousHash: ""}
+	first.BlockchainHash = contract.generateHash(first)
+	putVoteRecord(t, stub, first)
+
+	second := VoteRecord{VoterUUID: "voter-2", PreviousHash: "not-the-real-previous-hash"}
+	second.Timestamp = first.Timestamp.Add(1)
+	second.BlockchainHash = contract.generateHash(second)
+	putVoteRecord(t, stub, second)
+
+	result, err := contract.VerifyVoteIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("VerifyVoteIntegrity returned error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("VerifyVoteIntegrity did not detect a broken PreviousHash link")
+	}
+	if result.Height != 1 {
+		t.Fatalf("expected divergence at height 1, got %d", result.Height)
+	}
+}
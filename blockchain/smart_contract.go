@@ -1,13 +1,52 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
 )
 
+// txTimestamp returns the current transaction's deterministic timestamp.
+// Every endorsing peer simulates the same proposal with this same value,
+// unlike time.Now(), so it's the only clock safe to write to state or
+// fold into a hash.
+func txTimestamp(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	protoTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	ts, err := ptypes.Timestamp(protoTimestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to convert transaction timestamp: %v", err)
+	}
+
+	return ts, nil
+}
+
+// lastVoteHashKey tracks the blockchain hash of the most recently committed
+// vote record so new records can chain to it, mirroring a blockchain's
+// previous-block-hash linkage.
+//
+// Known bottleneck: every CommitVote across the whole election reads and
+// rewrites this one key, so any two CommitVote transactions endorsed
+// concurrently for different voters conflict under Fabric's MVCC and one
+// is invalidated at commit time, regardless of booth. A real blockchain
+// only has one party (the block proposer) extending the tip at a time;
+// this design has every voter racing to do so. If CommitVote throughput
+// becomes a bottleneck at election scale, advance the tip once per block
+// via a single designated writer, or partition the chain (e.g. one
+// sub-chain per polling booth) instead of serializing on one global key.
+const lastVoteHashKey = "last_vote_hash"
+
 // VoteAuthContract provides functions for managing vote authentication
 type VoteAuthContract struct {
 	contractapi.Contract
@@ -15,16 +54,16 @@ type VoteAuthContract struct {
 
 // VoteRecord represents a vote record on the blockchain
 type VoteRecord struct {
-	VoterUUID       string    `json:"voter_uuid"`
-	VoterID         string    `json:"voter_id"`
-	PollingBoothID  int       `json:"polling_booth_id"`
-	Timestamp       time.Time `json:"timestamp"`
-	IDVerified      bool      `json:"id_verified"`
-	FaceVerified    bool      `json:"face_verified"`
-	IrisVerified    bool      `json:"iris_verified"`
-	VoteCast        bool      `json:"vote_cast"`
-	BlockchainHash  string    `json:"blockchain_hash"`
-	PreviousHash    string    `json:"previous_hash"`
+	VoterUUID      string    `json:"voter_uuid"`
+	VoterID        string    `json:"voter_id"`
+	PollingBoothID int       `json:"polling_booth_id"`
+	Timestamp      time.Time `json:"timestamp"`
+	IDVerified     bool      `json:"id_verified"`
+	FaceVerified   bool      `json:"face_verified"`
+	IrisVerified   bool      `json:"iris_verified"`
+	VoteCast       bool      `json:"vote_cast"`
+	BlockchainHash string    `json:"blockchain_hash"`
+	PreviousHash   string    `json:"previous_hash"`
 }
 
 // VoterStatus represents the current status of a voter
@@ -38,126 +77,391 @@ type VoterStatus struct {
 
 // PollingBoothStats represents statistics for a polling booth
 type PollingBoothStats struct {
-	BoothID     int `json:"booth_id"`
-	TotalVotes  int `json:"total_votes"`
+	BoothID     int       `json:"booth_id"`
+	TotalVotes  int       `json:"total_votes"`
 	LastUpdated time.Time `json:"last_updated"`
 }
 
+// PaginatedVoteRecords is a page of vote records returned by a range or
+// rich query, along with the bookmark needed to fetch the next page.
+type PaginatedVoteRecords struct {
+	Records        []*VoteRecord `json:"records"`
+	Bookmark       string        `json:"bookmark"`
+	FetchedRecords int32         `json:"fetched_records"`
+}
+
+// ChainVerificationResult reports the outcome of walking the full vote
+// record chain looking for tampering.
+type ChainVerificationResult struct {
+	Valid         bool   `json:"valid"`
+	Height        int    `json:"height"`
+	DivergentHash string `json:"divergent_hash,omitempty"`
+}
+
+// VoterCommitment binds a voter's registered ECVRF-P256-SHA256-TAI public
+// key to an opaque commitment produced off-chain from their biometric
+// template, so the chaincode never has to learn the template itself.
+type VoterCommitment struct {
+	VoterUUID    string    `json:"voter_uuid"`
+	PublicKey    string    `json:"public_key"`
+	Commitment   string    `json:"commitment"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// VoteToken records a spent VRF-derived anonymous voting token. It is keyed
+// by its nullifier so a token can never be redeemed twice, while the token
+// itself carries no link back to the voter's identity.
+type VoteToken struct {
+	Nullifier string    `json:"nullifier"`
+	Round     string    `json:"round"`
+	TxID      string    `json:"tx_id"`
+	IssuedAt  time.Time `json:"issued_at"`
+}
+
+// voteTokenDomain separates vote-token VRF inputs from any other use of
+// ECVRF within this contract, so a proof generated for one purpose can
+// never be replayed as if it were for another.
+const voteTokenDomain = "vote_token"
+
+// pendingVerificationSteps lists the verification steps that must all
+// complete, each via its own single-writer key, before a vote can be
+// committed.
+var pendingVerificationSteps = []string{"id_verification", "face_verification", "iris_verification"}
+
+// PendingVerification records that a single verification step has
+// completed for a voter, ahead of the final vote being committed. Each
+// step is written to its own composite key so the three biometric
+// verifications arriving concurrently don't conflict under Fabric's MVCC.
+type PendingVerification struct {
+	VoterUUID      string    `json:"voter_uuid"`
+	VoterID        string    `json:"voter_id"`
+	PollingBoothID int       `json:"polling_booth_id"`
+	Step           string    `json:"step"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// Chaincode event names emitted at vote state transitions. The companion
+// voteauth/events client package decodes these for dashboards and
+// result-tallying services that want to react in near real time instead
+// of polling GetAllVoteRecords.
+const (
+	eventVerificationStepCompleted = "VerificationStepCompleted"
+	eventVoteCast                  = "VoteCast"
+	eventIntegrityViolation        = "IntegrityViolation"
+	eventBoothStatsUpdated         = "BoothStatsUpdated"
+)
+
+// eventPayloadVersion is bumped whenever the EventPayload shape changes,
+// so subscribers can detect and handle old payloads.
+const eventPayloadVersion = 1
+
+// EventPayload is the versioned JSON payload attached to every chaincode
+// event this contract emits.
+type EventPayload struct {
+	Version   int       `json:"version"`
+	VoterUUID string    `json:"voter_uuid,omitempty"`
+	BoothID   int       `json:"booth_id,omitempty"`
+	Step      string    `json:"step,omitempty"`
+	TxID      string    `json:"tx_id"`
+	BlockHash string    `json:"block_hash,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// emitEvent stamps payload with the current version and transaction ID
+// and sets it as a chaincode event under name.
+func (s *VoteAuthContract) emitEvent(ctx contractapi.TransactionContextInterface, name string, payload EventPayload) error {
+	payload.Version = eventPayloadVersion
+	payload.TxID = ctx.GetStub().GetTxID()
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event payload: %v", name, err)
+	}
+
+	return ctx.GetStub().SetEvent(name, payloadJSON)
+}
+
+// pendingVerificationKey builds the single-writer key for one voter's
+// verification step.
+func pendingVerificationKey(voterUUID string, step string) string {
+	return fmt.Sprintf("pending_%s_%s", voterUUID, step)
+}
+
+// registeredPublicKeyMarker is the value stored at a pubkey_<publicKey>
+// key to mark that key as registered, without linking it back to a voter.
+var registeredPublicKeyMarker = []byte{0x01}
+
+// registeredPublicKeyKey builds the key isPublicKeyRegistered looks up,
+// so SubmitVoteToken can check registration in O(1) instead of scanning
+// every registered voter on every redemption.
+func registeredPublicKeyKey(publicKey string) string {
+	return fmt.Sprintf("pubkey_%s", publicKey)
+}
+
 // InitLedger adds a base set of data to the ledger
 func (s *VoteAuthContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	// Initialize with empty state
 	return nil
 }
 
-// RecordVoteVerification records a vote verification step on the blockchain
+// RecordVoteVerification records a single biometric verification step for
+// a voter. Each step is written to its own pending key so that the
+// id/face/iris verifications arriving nearly simultaneously from
+// different peripherals never conflict with each other; CommitVote later
+// reads all three to produce the final vote record.
 func (s *VoteAuthContract) RecordVoteVerification(ctx contractapi.TransactionContextInterface, voterUUID string, voterID string, pollingBoothID int, verificationStep string) error {
+	validStep := false
+	for _, step := range pendingVerificationSteps {
+		if step == verificationStep {
+			validStep = true
+			break
+		}
+	}
+	if !validStep {
+		return fmt.Errorf("invalid verification step: %s", verificationStep)
+	}
+
 	// Check if voter has already voted
 	voterStatusJSON, err := ctx.GetStub().GetState(fmt.Sprintf("voter_%s", voterUUID))
 	if err != nil {
 		return fmt.Errorf("failed to read voter status: %v", err)
 	}
-
-	var voterStatus VoterStatus
 	if voterStatusJSON != nil {
-		err = json.Unmarshal(voterStatusJSON, &voterStatus)
-		if err != nil {
+		var voterStatus VoterStatus
+		if err := json.Unmarshal(voterStatusJSON, &voterStatus); err != nil {
 			return fmt.Errorf("failed to unmarshal voter status: %v", err)
 		}
-
 		if voterStatus.HasVoted {
 			return fmt.Errorf("voter %s has already voted", voterID)
 		}
-	} else {
-		// Initialize voter status
-		voterStatus = VoterStatus{
-			VoterUUID:      voterUUID,
-			VoterID:        voterID,
-			HasVoted:       false,
-			PollingBoothID: pollingBoothID,
-		}
 	}
 
-	// Get or create vote record
-	voteRecordKey := fmt.Sprintf("vote_%s", voterUUID)
-	voteRecordJSON, err := ctx.GetStub().GetState(voteRecordKey)
+	timestamp, err := txTimestamp(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to read vote record: %v", err)
+		return err
 	}
 
-	var voteRecord VoteRecord
-	if voteRecordJSON != nil {
-		err = json.Unmarshal(voteRecordJSON, &voteRecord)
+	pendingVerification := PendingVerification{
+		VoterUUID:      voterUUID,
+		VoterID:        voterID,
+		PollingBoothID: pollingBoothID,
+		Step:           verificationStep,
+		Timestamp:      timestamp,
+	}
+
+	pendingVerificationJSON, err := json.Marshal(pendingVerification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending verification: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(pendingVerificationKey(voterUUID, verificationStep), pendingVerificationJSON); err != nil {
+		return fmt.Errorf("failed to put pending verification: %v", err)
+	}
+
+	return s.emitEvent(ctx, eventVerificationStepCompleted, EventPayload{
+		VoterUUID: voterUUID,
+		BoothID:   pollingBoothID,
+		Step:      verificationStep,
+		Timestamp: pendingVerification.Timestamp,
+	})
+}
+
+// CommitVote atomically reads the three completed pending verification
+// steps for a voter, validates them, and produces the final chained
+// VoteRecord, clearing the pending keys so they don't accumulate.
+func (s *VoteAuthContract) CommitVote(ctx contractapi.TransactionContextInterface, voterUUID string) error {
+	// Check if voter has already voted
+	voterStatusJSON, err := ctx.GetStub().GetState(fmt.Sprintf("voter_%s", voterUUID))
+	if err != nil {
+		return fmt.Errorf("failed to read voter status: %v", err)
+	}
+
+	var voterStatus VoterStatus
+	if voterStatusJSON != nil {
+		if err := json.Unmarshal(voterStatusJSON, &voterStatus); err != nil {
+			return fmt.Errorf("failed to unmarshal voter status: %v", err)
+		}
+		if voterStatus.HasVoted {
+			return fmt.Errorf("voter %s has already voted", voterUUID)
+		}
+	}
+
+	pendingByStep := make(map[string]*PendingVerification, len(pendingVerificationSteps))
+	for _, step := range pendingVerificationSteps {
+		pendingJSON, err := ctx.GetStub().GetState(pendingVerificationKey(voterUUID, step))
 		if err != nil {
-			return fmt.Errorf("failed to unmarshal vote record: %v", err)
+			return fmt.Errorf("failed to read pending %s: %v", step, err)
 		}
-	} else {
-		// Create new vote record
-		voteRecord = VoteRecord{
-			VoterUUID:      voterUUID,
-			VoterID:        voterID,
-			PollingBoothID: pollingBoothID,
-			Timestamp:      time.Now(),
+		if pendingJSON == nil {
+			return fmt.Errorf("missing verification step %s for voter %s", step, voterUUID)
 		}
+
+		var pending PendingVerification
+		if err := json.Unmarshal(pendingJSON, &pending); err != nil {
+			return fmt.Errorf("failed to unmarshal pending %s: %v", step, err)
+		}
+		pendingByStep[step] = &pending
 	}
 
-	// Update verification status based on step
-	switch verificationStep {
-	case "id_verification":
-		voteRecord.IDVerified = true
-	case "face_verification":
-		voteRecord.FaceVerified = true
-	case "iris_verification":
-		voteRecord.IrisVerified = true
-	case "vote_cast":
-		if !voteRecord.IDVerified || !voteRecord.FaceVerified || !voteRecord.IrisVerified {
-			return fmt.Errorf("all verification steps must be completed before casting vote")
+	voterID := pendingByStep["id_verification"].VoterID
+	pollingBoothID := pendingByStep["id_verification"].PollingBoothID
+
+	for _, step := range pendingVerificationSteps {
+		pending := pendingByStep[step]
+		if pending.VoterID != voterID || pending.PollingBoothID != pollingBoothID {
+			return fmt.Errorf("verification step %s recorded voter_id/polling_booth_id (%s/%d) inconsistent with id_verification (%s/%d)",
+				step, pending.VoterID, pending.PollingBoothID, voterID, pollingBoothID)
 		}
-		voteRecord.VoteCast = true
-		voterStatus.HasVoted = true
-		voterStatus.VotedAt = time.Now()
-	default:
-		return fmt.Errorf("invalid verification step: %s", verificationStep)
 	}
 
-	// Generate blockchain hash
+	previousHashBytes, err := ctx.GetStub().GetState(lastVoteHashKey)
+	if err != nil {
+		return fmt.Errorf("failed to read last vote hash: %v", err)
+	}
+
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	voteRecord := VoteRecord{
+		VoterUUID:      voterUUID,
+		VoterID:        voterID,
+		PollingBoothID: pollingBoothID,
+		Timestamp:      timestamp,
+		IDVerified:     true,
+		FaceVerified:   true,
+		IrisVerified:   true,
+		VoteCast:       true,
+		PreviousHash:   string(previousHashBytes),
+	}
 	voteRecord.BlockchainHash = s.generateHash(voteRecord)
 
-	// Store updated vote record
-	voteRecordJSON, err = json.Marshal(voteRecord)
+	voteRecordJSON, err := json.Marshal(voteRecord)
 	if err != nil {
 		return fmt.Errorf("failed to marshal vote record: %v", err)
 	}
 
-	err = ctx.GetStub().PutState(voteRecordKey, voteRecordJSON)
-	if err != nil {
+	voteRecordKey := fmt.Sprintf("vote_%s", voterUUID)
+	if err := ctx.GetStub().PutState(voteRecordKey, voteRecordJSON); err != nil {
 		return fmt.Errorf("failed to put vote record: %v", err)
 	}
+	// Advance the chain tip so the next committed record links to this one
+	if err := ctx.GetStub().PutState(lastVoteHashKey, []byte(voteRecord.BlockchainHash)); err != nil {
+		return fmt.Errorf("failed to put last vote hash: %v", err)
+	}
+
+	voterStatus.VoterUUID = voterUUID
+	voterStatus.VoterID = voterID
+	voterStatus.PollingBoothID = pollingBoothID
+	voterStatus.HasVoted = true
+	voterStatus.VotedAt = timestamp
 
-	// Store updated voter status
 	voterStatusJSON, err = json.Marshal(voterStatus)
 	if err != nil {
 		return fmt.Errorf("failed to marshal voter status: %v", err)
 	}
 
-	err = ctx.GetStub().PutState(fmt.Sprintf("voter_%s", voterUUID), voterStatusJSON)
-	if err != nil {
+	voterStatusKey := fmt.Sprintf("voter_%s", voterUUID)
+	if err := ctx.GetStub().PutState(voterStatusKey, voterStatusJSON); err != nil {
 		return fmt.Errorf("failed to put voter status: %v", err)
 	}
+	if err := s.updatePollingBoothStats(ctx, pollingBoothID); err != nil {
+		return fmt.Errorf("failed to update polling booth stats: %v", err)
+	}
+
+	for _, step := range pendingVerificationSteps {
+		if err := ctx.GetStub().DelState(pendingVerificationKey(voterUUID, step)); err != nil {
+			return fmt.Errorf("failed to delete pending %s: %v", step, err)
+		}
+	}
 
-	// Update polling booth statistics if vote was cast
-	if verificationStep == "vote_cast" {
-		err = s.updatePollingBoothStats(ctx, pollingBoothID)
+	return s.emitEvent(ctx, eventVoteCast, EventPayload{
+		VoterUUID: voterUUID,
+		BoothID:   pollingBoothID,
+		BlockHash: voteRecord.BlockchainHash,
+		Timestamp: voteRecord.Timestamp,
+	})
+}
+
+// GetPendingVerifications retrieves the verification steps completed so
+// far for a voter that haven't yet been committed via CommitVote.
+func (s *VoteAuthContract) GetPendingVerifications(ctx contractapi.TransactionContextInterface, voterUUID string) ([]*PendingVerification, error) {
+	var pendingVerifications []*PendingVerification
+
+	for _, step := range pendingVerificationSteps {
+		pendingJSON, err := ctx.GetStub().GetState(pendingVerificationKey(voterUUID, step))
 		if err != nil {
-			return fmt.Errorf("failed to update polling booth stats: %v", err)
+			return nil, fmt.Errorf("failed to read pending %s: %v", step, err)
+		}
+		if pendingJSON == nil {
+			continue
 		}
+
+		var pending PendingVerification
+		if err := json.Unmarshal(pendingJSON, &pending); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pending %s: %v", step, err)
+		}
+		pendingVerifications = append(pendingVerifications, &pending)
 	}
 
-	return nil
+	return pendingVerifications, nil
+}
+
+// PurgeExpiredPending sweeps every pending verification key and deletes
+// ones older than ttlSeconds, so half-finished verification sessions
+// don't accumulate on the ledger. It returns the number of keys purged.
+//
+// ttlSeconds is a plain int64 rather than time.Duration: fabric-contract-api-go's
+// argument conversion maps time.Duration parameters onto the untyped
+// int64 reflect.Value it builds for integer-kinded types without a
+// final Convert to the declared parameter type, so a time.Duration
+// parameter panics on every invocation through the contract's generated
+// entrypoint.
+func (s *VoteAuthContract) PurgeExpiredPending(ctx contractapi.TransactionContextInterface, ttlSeconds int64) (int, error) {
+	ttl := time.Duration(ttlSeconds) * time.Second
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("pending_", "pending_~")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending verifications: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return purged, fmt.Errorf("failed to iterate pending verifications: %v", err)
+		}
+
+		var pending PendingVerification
+		if err := json.Unmarshal(queryResponse.Value, &pending); err != nil {
+			return purged, fmt.Errorf("failed to unmarshal pending verification: %v", err)
+		}
+
+		if now.Sub(pending.Timestamp) <= ttl {
+			continue
+		}
+
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return purged, fmt.Errorf("failed to delete pending %s: %v", queryResponse.Key, err)
+		}
+		purged++
+	}
+
+	return purged, nil
 }
 
 // GetVoteRecord retrieves a vote record by voter UUID
 func (s *VoteAuthContract) GetVoteRecord(ctx contractapi.TransactionContextInterface, voterUUID string) (*VoteRecord, error) {
-	voteRecordJSON, err := ctx.GetStub().GetState(fmt.Sprintf("vote_%s", voterUUID))
+	voteRecordKey := fmt.Sprintf("vote_%s", voterUUID)
+
+	voteRecordJSON, err := ctx.GetStub().GetState(voteRecordKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read vote record: %v", err)
 	}
@@ -167,8 +471,7 @@ func (s *VoteAuthContract) GetVoteRecord(ctx contractapi.TransactionContextInter
 	}
 
 	var voteRecord VoteRecord
-	err = json.Unmarshal(voteRecordJSON, &voteRecord)
-	if err != nil {
+	if err := json.Unmarshal(voteRecordJSON, &voteRecord); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal vote record: %v", err)
 	}
 
@@ -177,7 +480,9 @@ func (s *VoteAuthContract) GetVoteRecord(ctx contractapi.TransactionContextInter
 
 // GetVoterStatus retrieves voter status by voter UUID
 func (s *VoteAuthContract) GetVoterStatus(ctx contractapi.TransactionContextInterface, voterUUID string) (*VoterStatus, error) {
-	voterStatusJSON, err := ctx.GetStub().GetState(fmt.Sprintf("voter_%s", voterUUID))
+	voterStatusKey := fmt.Sprintf("voter_%s", voterUUID)
+
+	voterStatusJSON, err := ctx.GetStub().GetState(voterStatusKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read voter status: %v", err)
 	}
@@ -187,8 +492,7 @@ func (s *VoteAuthContract) GetVoterStatus(ctx contractapi.TransactionContextInte
 	}
 
 	var voterStatus VoterStatus
-	err = json.Unmarshal(voterStatusJSON, &voterStatus)
-	if err != nil {
+	if err := json.Unmarshal(voterStatusJSON, &voterStatus); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal voter status: %v", err)
 	}
 
@@ -197,7 +501,9 @@ func (s *VoteAuthContract) GetVoterStatus(ctx contractapi.TransactionContextInte
 
 // GetPollingBoothStats retrieves statistics for a polling booth
 func (s *VoteAuthContract) GetPollingBoothStats(ctx contractapi.TransactionContextInterface, boothID int) (*PollingBoothStats, error) {
-	statsJSON, err := ctx.GetStub().GetState(fmt.Sprintf("booth_stats_%d", boothID))
+	statsKey := fmt.Sprintf("booth_stats_%d", boothID)
+
+	statsJSON, err := ctx.GetStub().GetState(statsKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read booth stats: %v", err)
 	}
@@ -212,15 +518,16 @@ func (s *VoteAuthContract) GetPollingBoothStats(ctx contractapi.TransactionConte
 	}
 
 	var stats PollingBoothStats
-	err = json.Unmarshal(statsJSON, &stats)
-	if err != nil {
+	if err := json.Unmarshal(statsJSON, &stats); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal booth stats: %v", err)
 	}
 
 	return &stats, nil
 }
 
-// GetAllVoteRecords retrieves all vote records (for audit purposes)
+// GetAllVoteRecords retrieves all vote records (for audit purposes). For
+// large ledgers, prefer GetVoteRecordsPaginated so the full result set
+// doesn't have to be loaded into memory at once.
 func (s *VoteAuthContract) GetAllVoteRecords(ctx contractapi.TransactionContextInterface) ([]*VoteRecord, error) {
 	resultsIterator, err := ctx.GetStub().GetStateByRange("vote_", "vote_~")
 	if err != nil {
@@ -228,6 +535,112 @@ func (s *VoteAuthContract) GetAllVoteRecords(ctx contractapi.TransactionContextI
 	}
 	defer resultsIterator.Close()
 
+	return collectVoteRecords(resultsIterator)
+}
+
+// GetVoteRecordsPaginated retrieves a single page of vote records, so
+// audit exports don't have to load the entire result set into memory. If
+// selectorJSON is empty, it paginates over the full "vote_" key range;
+// otherwise it runs selectorJSON as a CouchDB rich query.
+func (s *VoteAuthContract) GetVoteRecordsPaginated(ctx contractapi.TransactionContextInterface, bookmark string, pageSize int32, selectorJSON string) (*PaginatedVoteRecords, error) {
+	var resultsIterator shim.StateQueryIteratorInterface
+	var metadata *peer.QueryResponseMetadata
+	var err error
+
+	if selectorJSON == "" {
+		resultsIterator, metadata, err = ctx.GetStub().GetStateByRangeWithPagination("vote_", "vote_~", pageSize, bookmark)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vote records page: %v", err)
+		}
+	} else {
+		resultsIterator, metadata, err = ctx.GetStub().GetQueryResultWithPagination(selectorJSON, pageSize, bookmark)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query vote records page: %v", err)
+		}
+	}
+	defer resultsIterator.Close()
+
+	voteRecords, err := collectVoteRecords(resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedVoteRecords{
+		Records:        voteRecords,
+		Bookmark:       metadata.GetBookmark(),
+		FetchedRecords: metadata.GetFetchedRecordsCount(),
+	}, nil
+}
+
+// QueryVotesByBooth returns vote records cast at boothID whose timestamp
+// falls within [from, to] (RFC 3339), using the polling_booth_id/timestamp
+// index.
+func (s *VoteAuthContract) QueryVotesByBooth(ctx contractapi.TransactionContextInterface, boothID int, from string, to string) ([]*VoteRecord, error) {
+	selector := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"polling_booth_id": boothID,
+			"timestamp": map[string]interface{}{
+				"$gte": from,
+				"$lte": to,
+			},
+		},
+		"use_index": []string{"_design/indexBoothDoc", "indexBooth"},
+	}
+	return s.runVoteRecordQuery(ctx, selector)
+}
+
+// QueryVotesByTimeRange returns vote records whose timestamp falls within
+// [from, to] (RFC 3339), using the timestamp index.
+func (s *VoteAuthContract) QueryVotesByTimeRange(ctx contractapi.TransactionContextInterface, from string, to string) ([]*VoteRecord, error) {
+	selector := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"timestamp": map[string]interface{}{
+				"$gte": from,
+				"$lte": to,
+			},
+		},
+		"use_index": []string{"_design/indexTimestampDoc", "indexTimestamp"},
+	}
+	return s.runVoteRecordQuery(ctx, selector)
+}
+
+// QueryIncompleteVerifications returns vote records whose vote_cast field
+// is false, using the vote_cast index. CommitVote only writes records with
+// vote_cast true, and verification steps still in progress are held under
+// pending_ keys (see GetPendingVerifications), which this query does not
+// search.
+func (s *VoteAuthContract) QueryIncompleteVerifications(ctx contractapi.TransactionContextInterface) ([]*VoteRecord, error) {
+	selector := map[string]interface{}{
+		"selector":  map[string]interface{}{"vote_cast": false},
+		"use_index": []string{"_design/indexVoteCastDoc", "indexVoteCast"},
+	}
+	return s.runVoteRecordQuery(ctx, selector)
+}
+
+// runVoteRecordQuery marshals selector into a CouchDB rich query, executes
+// it, and collects every matching vote record. selector is built from
+// map[string]interface{} (rather than hand-formatted JSON) so caller-
+// supplied values are JSON-escaped instead of interpolated into the query
+// string, where they could otherwise break out of their field and inject
+// additional Mango clauses.
+func (s *VoteAuthContract) runVoteRecordQuery(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]*VoteRecord, error) {
+	queryStringBytes, err := json.Marshal(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query selector: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryStringBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vote records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	return collectVoteRecords(resultsIterator)
+}
+
+// collectVoteRecords drains a state query iterator into a slice of vote
+// records.
+func collectVoteRecords(resultsIterator shim.StateQueryIteratorInterface) ([]*VoteRecord, error) {
 	var voteRecords []*VoteRecord
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
@@ -236,8 +649,7 @@ func (s *VoteAuthContract) GetAllVoteRecords(ctx contractapi.TransactionContextI
 		}
 
 		var voteRecord VoteRecord
-		err = json.Unmarshal(queryResponse.Value, &voteRecord)
-		if err != nil {
+		if err := json.Unmarshal(queryResponse.Value, &voteRecord); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal vote record: %v", err)
 		}
 		voteRecords = append(voteRecords, &voteRecord)
@@ -246,16 +658,211 @@ func (s *VoteAuthContract) GetAllVoteRecords(ctx contractapi.TransactionContextI
 	return voteRecords, nil
 }
 
-// VerifyVoteIntegrity verifies the integrity of a vote record
-func (s *VoteAuthContract) VerifyVoteIntegrity(ctx contractapi.TransactionContextInterface, voterUUID string) (bool, error) {
-	voteRecord, err := s.GetVoteRecord(ctx, voterUUID)
+// VerifyVoteIntegrity walks the entire vote record chain, sorted by
+// timestamp, confirming every record's hash is correct and that its
+// PreviousHash matches the hash of its predecessor. It returns the height
+// and hash at which the chain first diverges, if any.
+//
+// Callers MUST invoke this as a submitted transaction, not evaluated as a
+// read-only query. This function never calls PutState, so nothing stops a
+// client from invoking it via the query path, but the IntegrityViolation
+// event it emits on divergence is only delivered to voteauth/events
+// subscribers if the invocation is actually ordered into a block — Fabric
+// silently drops SetEvent calls made during Evaluate, and chaincode code
+// has no way to detect or reject that at runtime.
+func (s *VoteAuthContract) VerifyVoteIntegrity(ctx contractapi.TransactionContextInterface) (*ChainVerificationResult, error) {
+	voteRecords, err := s.GetAllVoteRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(voteRecords, func(i, j int) bool {
+		return voteRecords[i].Timestamp.Before(voteRecords[j].Timestamp)
+	})
+
+	previousHash := ""
+	for height, voteRecord := range voteRecords {
+		expectedHash := s.generateHash(*voteRecord)
+		if voteRecord.BlockchainHash != expectedHash || voteRecord.PreviousHash != previousHash {
+			timestamp, err := txTimestamp(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := s.emitEvent(ctx, eventIntegrityViolation, EventPayload{
+				VoterUUID: voteRecord.VoterUUID,
+				BoothID:   voteRecord.PollingBoothID,
+				BlockHash: voteRecord.BlockchainHash,
+				Timestamp: timestamp,
+			}); err != nil {
+				return nil, err
+			}
+
+			return &ChainVerificationResult{
+				Valid:         false,
+				Height:        height,
+				DivergentHash: voteRecord.BlockchainHash,
+			}, nil
+		}
+		previousHash = voteRecord.BlockchainHash
+	}
+
+	return &ChainVerificationResult{Valid: true, Height: len(voteRecords)}, nil
+}
+
+// RegisterVoterCommitment binds a voter's registered ECVRF public key to an
+// off-chain commitment so they can later submit an anonymous vote token
+// without the chaincode ever learning which biometric template matched.
+func (s *VoteAuthContract) RegisterVoterCommitment(ctx contractapi.TransactionContextInterface, voterUUID string, publicKey string, commitment string) error {
+	commitmentKey := fmt.Sprintf("commitment_%s", voterUUID)
+
+	existing, err := ctx.GetStub().GetState(commitmentKey)
+	if err != nil {
+		return fmt.Errorf("failed to read voter commitment: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("voter %s already has a registered commitment", voterUUID)
+	}
+
+	pubKeyBytes, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("public key must be hex-encoded: %v", err)
+	}
+	if err := ecvrfValidatePublicKey(pubKeyBytes); err != nil {
+		return fmt.Errorf("public key must be a compressed P-256 point: %v", err)
+	}
+
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	voterCommitment := VoterCommitment{
+		VoterUUID:    voterUUID,
+		PublicKey:    publicKey,
+		Commitment:   commitment,
+		RegisteredAt: timestamp,
+	}
+
+	voterCommitmentJSON, err := json.Marshal(voterCommitment)
 	if err != nil {
-		return false, err
+		return fmt.Errorf("failed to marshal voter commitment: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(commitmentKey, voterCommitmentJSON); err != nil {
+		return fmt.Errorf("failed to put voter commitment: %v", err)
 	}
 
-	// Recalculate hash and compare
-	expectedHash := s.generateHash(*voteRecord)
-	return voteRecord.BlockchainHash == expectedHash, nil
+	return ctx.GetStub().PutState(registeredPublicKeyKey(publicKey), registeredPublicKeyMarker)
+}
+
+// SubmitVoteToken redeems a VRF-derived anonymous voting token for the
+// given polling round. vrfProof must be a valid ECVRF-P256-SHA256-TAI proof,
+// over alpha = voteTokenDomain||0x00||round||0x00||the chain tip's last vote
+// hash (see vrfTokenAlpha), produced by the private key matching a
+// registered public key. ECVRFVerify both authenticates the proof against
+// that key and input and recovers the one deterministic output the pair can
+// ever produce, so a registered voter cannot mint more than one nullifier
+// per round and chain tip by varying an unchecked parameter the way an
+// arbitrary caller-supplied digest would have allowed. Each CommitVote
+// advances the chain tip, which changes alpha, so the same key can produce
+// a new valid nullifier for the same round after the tip moves.
+// Neither the voter's UUID nor their commitment is required, so the
+// chaincode cannot link the token back to a specific voter.
+func (s *VoteAuthContract) SubmitVoteToken(ctx contractapi.TransactionContextInterface, publicKey string, vrfProof string, round string) (string, error) {
+	pubKeyBytes, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("public key must be hex-encoded: %v", err)
+	}
+
+	registered, err := s.isPublicKeyRegistered(ctx, publicKey)
+	if err != nil {
+		return "", err
+	}
+	if !registered {
+		return "", fmt.Errorf("public key is not registered for voting")
+	}
+
+	proofBytes, err := hex.DecodeString(vrfProof)
+	if err != nil {
+		return "", fmt.Errorf("vrf proof must be hex-encoded: %v", err)
+	}
+
+	previousHash, err := ctx.GetStub().GetState(lastVoteHashKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read last vote hash: %v", err)
+	}
+
+	alpha := vrfTokenAlpha(round, previousHash)
+
+	beta, err := ECVRFVerify(pubKeyBytes, alpha, proofBytes)
+	if err != nil {
+		return "", fmt.Errorf("vrf proof did not verify: %v", err)
+	}
+
+	nullifier := hex.EncodeToString(beta)
+	nullifierKey := fmt.Sprintf("nullifier_%s", nullifier)
+
+	existing, err := ctx.GetStub().GetState(nullifierKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read nullifier: %v", err)
+	}
+	if existing != nil {
+		return "", fmt.Errorf("vote token has already been submitted")
+	}
+
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	voteToken := VoteToken{
+		Nullifier: nullifier,
+		Round:     round,
+		TxID:      ctx.GetStub().GetTxID(),
+		IssuedAt:  timestamp,
+	}
+
+	voteTokenJSON, err := json.Marshal(voteToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal vote token: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(nullifierKey, voteTokenJSON); err != nil {
+		return "", fmt.Errorf("failed to put vote token: %v", err)
+	}
+
+	return nullifier, nil
+}
+
+// vrfTokenAlpha builds the ECVRF input a vote token proof must be generated
+// over: the vote-token domain tag, the polling round, and the chain tip's
+// last vote hash at submission time. Binding to the chain tip (rather than
+// a caller-supplied value) means the input isn't freely choosable by the
+// prover.
+func vrfTokenAlpha(round string, previousHash []byte) []byte {
+	alpha := []byte(voteTokenDomain)
+	alpha = append(alpha, 0x00)
+	alpha = append(alpha, []byte(round)...)
+	alpha = append(alpha, 0x00)
+	alpha = append(alpha, previousHash...)
+	return alpha
+}
+
+// isPublicKeyRegistered reports whether publicKey was registered via
+// RegisterVoterCommitment, without revealing which voter UUID it belongs
+// to. This is an O(1) lookup against the pubkey_<publicKey> marker
+// RegisterVoterCommitment writes, rather than a GetStateByRange scan over
+// every registered voter — SubmitVoteToken calls this once per cast
+// ballot, so scanning the full commitment set on every redemption doesn't
+// scale to election-sized elector rolls.
+func (s *VoteAuthContract) isPublicKeyRegistered(ctx contractapi.TransactionContextInterface, publicKey string) (bool, error) {
+	marker, err := ctx.GetStub().GetState(registeredPublicKeyKey(publicKey))
+	if err != nil {
+		return false, fmt.Errorf("failed to read public key registration: %v", err)
+	}
+
+	return marker != nil, nil
 }
 
 // Helper function to update polling booth statistics
@@ -279,61 +886,60 @@ func (s *VoteAuthContract) updatePollingBoothStats(ctx contractapi.TransactionCo
 		}
 	}
 
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
 	stats.TotalVotes++
-	stats.LastUpdated = time.Now()
+	stats.LastUpdated = timestamp
 
 	statsJSON, err = json.Marshal(stats)
 	if err != nil {
 		return fmt.Errorf("failed to marshal booth stats: %v", err)
 	}
 
-	return ctx.GetStub().PutState(statsKey, statsJSON)
+	if err := ctx.GetStub().PutState(statsKey, statsJSON); err != nil {
+		return fmt.Errorf("failed to put booth stats: %v", err)
+	}
+
+	return s.emitEvent(ctx, eventBoothStatsUpdated, EventPayload{
+		BoothID:   boothID,
+		Timestamp: stats.LastUpdated,
+	})
 }
 
-// Helper function to generate a hash for vote record
+// Helper function to generate a tamper-evident hash for a vote record.
+// The hash is a SHA-256 digest over a canonical, deterministic
+// serialization of the record's fields, including PreviousHash so records
+// chain together like a mini-blockchain.
 func (s *VoteAuthContract) generateHash(voteRecord VoteRecord) string {
-	// Simple hash generation - in production, use proper cryptographic hash
-	data := fmt.Sprintf("%s_%s_%d_%v_%v_%v_%v",
+	data := fmt.Sprintf("%s_%s_%d_%d_%v_%v_%v_%v_%s",
 		voteRecord.VoterUUID,
 		voteRecord.VoterID,
 		voteRecord.PollingBoothID,
+		voteRecord.Timestamp.UnixNano(),
 		voteRecord.IDVerified,
 		voteRecord.FaceVerified,
 		voteRecord.IrisVerified,
 		voteRecord.VoteCast,
+		voteRecord.PreviousHash,
 	)
-	
-	// In production, use SHA-256 or similar
-	return fmt.Sprintf("hash_%x", len(data)*17+42) // Simplified hash
+
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
 }
 
-// GetVoteHistory retrieves vote history for audit trail
+// GetVoteHistory retrieves vote history for audit trail. For large
+// ledgers, prefer GetVoteRecordsPaginated with a voter_id selector so the
+// full result set doesn't have to be loaded into memory at once.
 func (s *VoteAuthContract) GetVoteHistory(ctx contractapi.TransactionContextInterface, voterID string) ([]*VoteRecord, error) {
 	// Query by voter ID
-	queryString := fmt.Sprintf(`{"selector":{"voter_id":"%s"}}`, voterID)
-	
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query vote history: %v", err)
+	selector := map[string]interface{}{
+		"selector":  map[string]interface{}{"voter_id": voterID},
+		"use_index": []string{"_design/indexVoterIDDoc", "indexVoterID"},
 	}
-	defer resultsIterator.Close()
-
-	var voteRecords []*VoteRecord
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, fmt.Errorf("failed to iterate vote history: %v", err)
-		}
-
-		var voteRecord VoteRecord
-		err = json.Unmarshal(queryResponse.Value, &voteRecord)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal vote record: %v", err)
-		}
-		voteRecords = append(voteRecords, &voteRecord)
-	}
-
-	return voteRecords, nil
+	return s.runVoteRecordQuery(ctx, selector)
 }
 
 func main() {
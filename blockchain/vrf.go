@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ECVRF-P256-SHA256-TAI (RFC 9381) verify-only implementation.
+//
+// A vote token must be unforgeable and, for a given registered key and
+// VRF input, unique: the holder of a private key can't mint more than one
+// valid nullifier for the same input by varying an unchecked parameter.
+// A real VRF gives both properties at once — Verify both authenticates
+// that proof was produced by the claimed private key for alpha, and
+// recovers the one deterministic output (beta) that pair can ever
+// produce, so the nullifier derived from beta can't be freely chosen.
+
+const (
+	ecvrfSuiteP256SHA256TAI  = 0x01
+	ecvrfPointLen            = 33 // compressed P-256 point: 1-byte parity + 32-byte X
+	ecvrfCLen                = 16 // ceil(qLen/2) for a 32-byte group order
+	ecvrfQLen                = 32
+	ecvrfProofLen            = ecvrfPointLen + ecvrfCLen + ecvrfQLen
+	ecvrfMaxHashToCurveTries = 256
+)
+
+func ecvrfCurve() elliptic.Curve {
+	return elliptic.P256()
+}
+
+// ecvrfValidatePublicKey reports whether publicKey is a compressed P-256
+// point usable as a VRF public key.
+func ecvrfValidatePublicKey(publicKey []byte) error {
+	x, y := elliptic.UnmarshalCompressed(ecvrfCurve(), publicKey)
+	if x == nil || y == nil {
+		return errors.New("not a valid compressed P-256 point")
+	}
+	return nil
+}
+
+// ECVRFVerify checks proof against publicKey and alpha per RFC 9381 section
+// 5.3 and, if it verifies, returns beta: the unique deterministic output
+// that (publicKey, alpha) pair produces under this VRF.
+func ECVRFVerify(publicKey []byte, alpha []byte, proof []byte) ([]byte, error) {
+	curve := ecvrfCurve()
+
+	Yx, Yy := elliptic.UnmarshalCompressed(curve, publicKey)
+	if Yx == nil {
+		return nil, errors.New("invalid vrf public key")
+	}
+
+	gammaX, gammaY, c, s, err := ecvrfDecodeProof(curve, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	Hx, Hy, err := ecvrfHashToCurve(curve, publicKey, alpha)
+	if err != nil {
+		return nil, err
+	}
+
+	// U = s*B - c*Y
+	sBx, sBy := curve.ScalarBaseMult(s.Bytes())
+	cYx, cYy := curve.ScalarMult(Yx, Yy, c.Bytes())
+	negCYx, negCYy := ecvrfNegate(curve, cYx, cYy)
+	Ux, Uy := curve.Add(sBx, sBy, negCYx, negCYy)
+
+	// V = s*H - c*Gamma
+	sHx, sHy := curve.ScalarMult(Hx, Hy, s.Bytes())
+	cGx, cGy := curve.ScalarMult(gammaX, gammaY, c.Bytes())
+	negCGx, negCGy := ecvrfNegate(curve, cGx, cGy)
+	Vx, Vy := curve.Add(sHx, sHy, negCGx, negCGy)
+
+	cPrime := ecvrfHashPoints(curve, Yx, Yy, Hx, Hy, gammaX, gammaY, Ux, Uy, Vx, Vy)
+	if cPrime.Cmp(c) != 0 {
+		return nil, errors.New("vrf proof does not verify against the public key and input")
+	}
+
+	return ecvrfProofToHash(curve, gammaX, gammaY), nil
+}
+
+// ecvrfDecodeProof splits a proof into its Gamma point, c and s components.
+func ecvrfDecodeProof(curve elliptic.Curve, proof []byte) (gammaX, gammaY, c, s *big.Int, err error) {
+	if len(proof) != ecvrfProofLen {
+		return nil, nil, nil, nil, fmt.Errorf("vrf proof must be %d bytes, got %d", ecvrfProofLen, len(proof))
+	}
+
+	gammaX, gammaY = elliptic.UnmarshalCompressed(curve, proof[:ecvrfPointLen])
+	if gammaX == nil {
+		return nil, nil, nil, nil, errors.New("invalid gamma point in vrf proof")
+	}
+
+	c = new(big.Int).SetBytes(proof[ecvrfPointLen : ecvrfPointLen+ecvrfCLen])
+	s = new(big.Int).SetBytes(proof[ecvrfPointLen+ecvrfCLen:])
+	return gammaX, gammaY, c, s, nil
+}
+
+// ecvrfHashToCurve deterministically maps alpha onto a curve point via
+// try-and-increment, as ECVRF-P256-SHA256-TAI requires.
+func ecvrfHashToCurve(curve elliptic.Curve, publicKey []byte, alpha []byte) (*big.Int, *big.Int, error) {
+	for ctr := 0; ctr < ecvrfMaxHashToCurveTries; ctr++ {
+		h := sha256.New()
+		h.Write([]byte{ecvrfSuiteP256SHA256TAI, 0x01})
+		h.Write(publicKey)
+		h.Write(alpha)
+		h.Write([]byte{byte(ctr)})
+		h.Write([]byte{0x00})
+		sum := h.Sum(nil)
+
+		candidate := append([]byte{0x02}, sum...)
+		if x, y := elliptic.UnmarshalCompressed(curve, candidate); x != nil {
+			return x, y, nil
+		}
+	}
+	return nil, nil, errors.New("failed to hash vrf input onto the curve")
+}
+
+// ecvrfHashPoints is ECVRF_challenge_generation (RFC 9381 section 5.4.3):
+// Fiat-Shamir challenge generation over (Y, H, Gamma, U, V), folding the
+// public key and every point in the proof's verification equations into a
+// single cLen-byte challenge.
+func ecvrfHashPoints(curve elliptic.Curve, yx, yy, hx, hy, gammaX, gammaY, ux, uy, vx, vy *big.Int) *big.Int {
+	h := sha256.New()
+	h.Write([]byte{ecvrfSuiteP256SHA256TAI, 0x02})
+	h.Write(elliptic.MarshalCompressed(curve, yx, yy))
+	h.Write(elliptic.MarshalCompressed(curve, hx, hy))
+	h.Write(elliptic.MarshalCompressed(curve, gammaX, gammaY))
+	h.Write(elliptic.MarshalCompressed(curve, ux, uy))
+	h.Write(elliptic.MarshalCompressed(curve, vx, vy))
+	h.Write([]byte{0x00})
+	sum := h.Sum(nil)
+	return new(big.Int).SetBytes(sum[:ecvrfCLen])
+}
+
+// ecvrfProofToHash derives the VRF's final output, beta, from a verified
+// Gamma point.
+func ecvrfProofToHash(curve elliptic.Curve, gammaX, gammaY *big.Int) []byte {
+	h := sha256.New()
+	h.Write([]byte{ecvrfSuiteP256SHA256TAI, 0x03})
+	h.Write(elliptic.MarshalCompressed(curve, gammaX, gammaY))
+	h.Write([]byte{0x00})
+	return h.Sum(nil)
+}
+
+// ecvrfNegate returns the additive inverse of a curve point, used to turn
+// the proof's two subtractions into the additions elliptic.Curve exposes.
+func ecvrfNegate(curve elliptic.Curve, x, y *big.Int) (*big.Int, *big.Int) {
+	negY := new(big.Int).Sub(curve.Params().P, y)
+	negY.Mod(negY, curve.Params().P)
+	return new(big.Int).Set(x), negY
+}
@@ -0,0 +1,114 @@
+// Package events provides a typed client for the VoteAuthContract
+// chaincode's events, so dashboards and result-tallying services can
+// react in near real time instead of polling GetAllVoteRecords.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// Name identifies which VoteAuthContract chaincode event a Notification
+// carries.
+type Name string
+
+// Event names emitted by the VoteAuthContract chaincode. Keep in sync
+// with the eventXxx constants in blockchain/smart_contract.go.
+const (
+	VerificationStepCompleted Name = "VerificationStepCompleted"
+	VoteCast                  Name = "VoteCast"
+	IntegrityViolation        Name = "IntegrityViolation"
+	BoothStatsUpdated         Name = "BoothStatsUpdated"
+)
+
+// Payload mirrors the versioned JSON payload VoteAuthContract attaches to
+// every chaincode event.
+type Payload struct {
+	Version   int       `json:"version"`
+	VoterUUID string    `json:"voter_uuid,omitempty"`
+	BoothID   int       `json:"booth_id,omitempty"`
+	Step      string    `json:"step,omitempty"`
+	TxID      string    `json:"tx_id"`
+	BlockHash string    `json:"block_hash,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notification is a decoded chaincode event, ready for a subscriber to
+// consume without parsing raw Fabric SDK types.
+type Notification struct {
+	Name        Name
+	Payload     Payload
+	TxID        string
+	BlockNumber uint64
+}
+
+// Handler processes a decoded Notification.
+type Handler func(Notification)
+
+// EventListener wraps the Fabric SDK's chaincode event channel, decoding
+// VoteAuthContract events into typed Notifications. To resume from a
+// specific block height rather than only receiving new events, configure
+// the underlying event.Client with event.WithBlockNum before passing it
+// to NewEventListener.
+type EventListener struct {
+	client      *event.Client
+	chaincodeID string
+}
+
+// NewEventListener creates a listener for chaincodeID's events on client.
+func NewEventListener(client *event.Client, chaincodeID string) *EventListener {
+	return &EventListener{client: client, chaincodeID: chaincodeID}
+}
+
+// Listen subscribes to chaincode events whose name matches eventFilter (a
+// regular expression; "" matches every event name this contract emits)
+// and invokes handle for each one decoded, until ctx is cancelled, the
+// event channel closes, or a payload fails to decode.
+func (l *EventListener) Listen(ctx context.Context, eventFilter string, handle Handler) error {
+	if eventFilter == "" {
+		eventFilter = ".*"
+	}
+
+	registration, eventCh, err := l.client.RegisterChaincodeEvent(l.chaincodeID, eventFilter)
+	if err != nil {
+		return fmt.Errorf("failed to register for chaincode events: %v", err)
+	}
+	defer l.client.Unregister(registration)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ccEvent, ok := <-eventCh:
+			if !ok {
+				return fmt.Errorf("chaincode event channel closed")
+			}
+
+			notification, err := decode(ccEvent)
+			if err != nil {
+				return err
+			}
+			handle(notification)
+		}
+	}
+}
+
+// decode converts a raw fab.CCEvent into a typed Notification.
+func decode(ccEvent *fab.CCEvent) (Notification, error) {
+	var payload Payload
+	if err := json.Unmarshal(ccEvent.Payload, &payload); err != nil {
+		return Notification{}, fmt.Errorf("failed to unmarshal event payload: %v", err)
+	}
+
+	return Notification{
+		Name:        Name(ccEvent.EventName),
+		Payload:     payload,
+		TxID:        ccEvent.TxID,
+		BlockNumber: ccEvent.BlockNumber,
+	}, nil
+}